@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultWhisperUploadBytes is the default split threshold, kept just
+	// under OpenAI's 25 MB cap per transcription request. Audio files above
+	// this are split into overlapping chunks before upload. Overridable via config.WhisperChunkThresholdBytes
+	// (WHISPER_CHUNK_THRESHOLD_BYTES).
+	defaultWhisperUploadBytes = 24 * 1024 * 1024
+
+	chunkSegmentSeconds = 600 // ~10 minutes per chunk
+	chunkOverlapSeconds = 2   // overlap to avoid clipping words at boundaries
+	chunkWorkerCount    = 3   // concurrent Whisper uploads, bounded for rate limits
+
+	// minChunkSeconds is the shortest trailing segment splitAudioFile will
+	// cut on its own. Without this floor, a duration just past a
+	// chunkSegmentSeconds boundary produces a near-empty final chunk that
+	// Whisper is likely to reject; such a segment is folded into the
+	// previous one instead.
+	minChunkSeconds = 30
+)
+
+// audioChunk describes one split segment of a source audio file and the
+// offset (in seconds) at which it begins within the original file.
+type audioChunk struct {
+	path  string
+	start float64
+}
+
+// transcribeAudioChunked transcribes an audio file of any length, splitting
+// it into overlapping ~10-minute segments with ffmpeg when it exceeds
+// config.WhisperChunkThresholdBytes, transcribing the segments
+// concurrently, and merging the results back into a single TranscriptData.
+// Short files are passed straight through to transcribeAudioWithTimestamps
+// unchanged.
+func transcribeAudioChunked(audioFilePath string) (TranscriptData, error) {
+	return transcribeAudioChunkedProgress(context.Background(), audioFilePath, nil)
+}
+
+// transcribeAudioChunkedProgress behaves like transcribeAudioChunked but
+// reports cumulative bytes uploaded vs. the combined size of every chunk
+// as onProgress, aggregating the concurrent per-chunk upload progress into
+// a single running total. onProgress may be nil. ctx is checked between
+// chunk dispatches and threaded into each chunk's Whisper request, so a
+// canceled ctx (e.g. a disconnected SSE client) stops queuing new chunk
+// uploads and aborts ones already in flight instead of running the whole
+// job to completion for nobody.
+func transcribeAudioChunkedProgress(ctx context.Context, audioFilePath string, onProgress func(read, total int64)) (TranscriptData, error) {
+	info, err := os.Stat(audioFilePath)
+	if err != nil {
+		return TranscriptData{}, fmt.Errorf("failed to stat audio file: %v", err)
+	}
+
+	if info.Size() <= config.WhisperChunkThresholdBytes {
+		return transcribeAudioWithTimestampsProgress(ctx, audioFilePath, onProgress)
+	}
+
+	chunkDir, err := os.MkdirTemp("", "avi-chunks-*")
+	if err != nil {
+		return TranscriptData{}, fmt.Errorf("failed to create chunk directory: %v", err)
+	}
+	defer os.RemoveAll(chunkDir)
+
+	chunks, err := splitAudioFile(audioFilePath, chunkDir)
+	if err != nil {
+		return TranscriptData{}, fmt.Errorf("failed to split audio file: %v", err)
+	}
+
+	results := make([]TranscriptData, len(chunks))
+	errs := make([]error, len(chunks))
+
+	reportChunkProgress, err := newChunkProgressReporter(chunks, onProgress)
+	if err != nil {
+		return TranscriptData{}, err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, chunkWorkerCount)
+
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk audioChunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = transcribeAudioWithTimestampsProgress(ctx, chunk.path, func(read, total int64) {
+				reportChunkProgress(i, read)
+			})
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return TranscriptData{}, fmt.Errorf("failed to transcribe chunk %d: %v", i, err)
+		}
+	}
+
+	return mergeChunkedTranscripts(chunks, results), nil
+}
+
+// newChunkProgressReporter returns a function that reports the sum of
+// bytes uploaded across all chunks so far against their combined total
+// size, suitable for sharing between concurrently-uploading chunks.
+// onProgress may be nil, in which case the returned function is a no-op.
+func newChunkProgressReporter(chunks []audioChunk, onProgress func(read, total int64)) (func(chunkIndex int, read int64), error) {
+	if onProgress == nil {
+		return func(int, int64) {}, nil
+	}
+
+	var totalBytes int64
+	for i, chunk := range chunks {
+		info, err := os.Stat(chunk.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat chunk %d: %v", i, err)
+		}
+		totalBytes += info.Size()
+	}
+
+	var mu sync.Mutex
+	reads := make([]int64, len(chunks))
+
+	return func(chunkIndex int, read int64) {
+		mu.Lock()
+		reads[chunkIndex] = read
+		var sum int64
+		for _, r := range reads {
+			sum += r
+		}
+		mu.Unlock()
+		onProgress(sum, totalBytes)
+	}, nil
+}
+
+// probeDuration returns the duration in seconds of the given media file
+// using ffprobe.
+func probeDuration(path string) (float64, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "csv=p=0", path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %v, output: %s", err, string(output))
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ffprobe duration: %v", err)
+	}
+	return duration, nil
+}
+
+// chunkSpan is a planned [start, start+length) cut of the source file,
+// before ffmpeg has actually produced the piece on disk.
+type chunkSpan struct {
+	start, length float64
+}
+
+// planChunkSpans lays out the start/length of each chunk to cut from a
+// file of the given duration, then folds a too-short trailing span into
+// the one before it so splitAudioFile never emits a near-empty chunk.
+//
+// Without the fold, a duration just past a chunkSegmentSeconds multiple
+// (e.g. 601s) clamps the first span to cover the entire file and leaves a
+// useless 1-second span after it.
+func planChunkSpans(duration float64) []chunkSpan {
+	var spans []chunkSpan
+	for start := 0.0; start < duration; start += chunkSegmentSeconds {
+		length := float64(chunkSegmentSeconds + chunkOverlapSeconds)
+		if start+length > duration {
+			length = duration - start
+		}
+		spans = append(spans, chunkSpan{start: start, length: length})
+	}
+
+	for len(spans) > 1 && spans[len(spans)-1].length < minChunkSeconds {
+		last := spans[len(spans)-1]
+		spans = spans[:len(spans)-1]
+		prev := &spans[len(spans)-1]
+		prev.length = last.start + last.length - prev.start
+	}
+
+	return spans
+}
+
+// splitAudioFile uses ffmpeg to cut audioFilePath into overlapping
+// chunkSegmentSeconds-long pieces, each extended by chunkOverlapSeconds so
+// words at the boundary aren't clipped from either side.
+func splitAudioFile(audioFilePath, outDir string) ([]audioChunk, error) {
+	duration, err := probeDuration(audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []audioChunk
+	for _, span := range planChunkSpans(duration) {
+		outPath := filepath.Join(outDir, fmt.Sprintf("chunk_%03d.mp3", len(chunks)))
+		args := []string{
+			"-y",
+			"-ss", fmt.Sprintf("%.3f", span.start),
+			"-t", fmt.Sprintf("%.3f", span.length),
+			"-i", audioFilePath,
+			"-c", "copy",
+			outPath,
+		}
+
+		cmd := exec.Command("ffmpeg", args...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("ffmpeg failed on chunk starting at %.0fs: %v, output: %s", span.start, err, string(output))
+		}
+
+		chunks = append(chunks, audioChunk{path: outPath, start: span.start})
+	}
+
+	return chunks, nil
+}
+
+// mergeChunkedTranscripts offsets each chunk's segment timestamps by the
+// chunk's start time, renumbers IDs sequentially, and trims text that
+// duplicates the tail of the previous chunk within the overlap window.
+func mergeChunkedTranscripts(chunks []audioChunk, results []TranscriptData) TranscriptData {
+	var segments []TranscriptSegment
+	var fullText strings.Builder
+	nextID := 1
+	lastText := ""
+
+	for i, data := range results {
+		offset := chunks[i].start
+
+		for j, seg := range data.Segments {
+			text := seg.Text
+			if i > 0 && j == 0 {
+				text = trimOverlapPrefix(lastText, text)
+			}
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			segments = append(segments, TranscriptSegment{
+				ID:    nextID,
+				Start: seg.Start + offset,
+				End:   seg.End + offset,
+				Text:  text,
+			})
+			nextID++
+			fullText.WriteString(text)
+			fullText.WriteString(" ")
+		}
+
+		if len(data.Segments) > 0 {
+			lastText = data.Segments[len(data.Segments)-1].Text
+		}
+	}
+
+	duration := 0.0
+	if len(segments) > 0 {
+		duration = segments[len(segments)-1].End
+	}
+
+	return TranscriptData{
+		Text:     strings.TrimSpace(fullText.String()),
+		Segments: segments,
+		Language: firstNonEmptyLanguage(results),
+		Duration: duration,
+	}
+}
+
+// trimOverlapPrefix removes the leading portion of next that duplicates the
+// trailing portion of prev, via the longest matching suffix/prefix pair.
+func trimOverlapPrefix(prev, next string) string {
+	maxLen := len(prev)
+	if len(next) < maxLen {
+		maxLen = len(next)
+	}
+
+	for l := maxLen; l > 0; l-- {
+		if strings.EqualFold(strings.TrimSpace(prev[len(prev)-l:]), strings.TrimSpace(next[:l])) {
+			return strings.TrimSpace(next[l:])
+		}
+	}
+	return next
+}
+
+func firstNonEmptyLanguage(results []TranscriptData) string {
+	for _, r := range results {
+		if r.Language != "" {
+			return r.Language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanChunkSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		want     []chunkSpan
+	}{
+		{
+			name:     "single chunk under the segment length",
+			duration: 120,
+			want:     []chunkSpan{{start: 0, length: 120}},
+		},
+		{
+			name:     "two full-length chunks and a trailing remainder",
+			duration: 1250,
+			want: []chunkSpan{
+				{start: 0, length: 602},
+				{start: 600, length: 602},
+				{start: 1200, length: 50},
+			},
+		},
+		{
+			name:     "trailing sliver just past a segment boundary folds into the previous chunk",
+			duration: 601,
+			want:     []chunkSpan{{start: 0, length: 601}},
+		},
+		{
+			name:     "trailing sliver well past a segment boundary still folds",
+			duration: 620,
+			want:     []chunkSpan{{start: 0, length: 620}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planChunkSpans(tt.duration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planChunkSpans(%v) = %+v, want %+v", tt.duration, got, tt.want)
+			}
+			for _, span := range got {
+				if span.length < minChunkSeconds && len(got) > 1 {
+					t.Errorf("planChunkSpans(%v) produced a %fs chunk below minChunkSeconds", tt.duration, span.length)
+				}
+			}
+		})
+	}
+}
+
+func TestTrimOverlapPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		next string
+		want string
+	}{
+		{
+			name: "no overlap",
+			prev: "hello there",
+			next: "general kenobi",
+			want: "general kenobi",
+		},
+		{
+			name: "exact duplicate suffix/prefix",
+			prev: "the quick brown fox",
+			next: "brown fox jumps over",
+			want: "jumps over",
+		},
+		{
+			name: "case-insensitive match",
+			prev: "and then she said",
+			next: "SHE SAID goodbye",
+			want: "goodbye",
+		},
+		{
+			name: "empty prev",
+			prev: "",
+			next: "fresh start",
+			want: "fresh start",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimOverlapPrefix(tt.prev, tt.next); got != tt.want {
+				t.Errorf("trimOverlapPrefix(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeChunkedTranscripts(t *testing.T) {
+	chunks := []audioChunk{
+		{path: "chunk_000.mp3", start: 0},
+		{path: "chunk_001.mp3", start: 600},
+	}
+	results := []TranscriptData{
+		{
+			Language: "en",
+			Segments: []TranscriptSegment{
+				{ID: 1, Start: 0, End: 5, Text: "the quick brown fox"},
+				{ID: 2, Start: 5, End: 10, Text: "jumps over the lazy dog"},
+			},
+		},
+		{
+			Language: "en",
+			Segments: []TranscriptSegment{
+				{ID: 1, Start: 0, End: 4, Text: "the lazy dog"},
+				{ID: 2, Start: 4, End: 8, Text: "ran away"},
+			},
+		},
+	}
+
+	got := mergeChunkedTranscripts(chunks, results)
+
+	wantSegments := []TranscriptSegment{
+		{ID: 1, Start: 0, End: 5, Text: "the quick brown fox"},
+		{ID: 2, Start: 5, End: 10, Text: "jumps over the lazy dog"},
+		{ID: 3, Start: 604, End: 608, Text: "ran away"},
+	}
+	if !reflect.DeepEqual(got.Segments, wantSegments) {
+		t.Errorf("mergeChunkedTranscripts segments = %+v, want %+v", got.Segments, wantSegments)
+	}
+	if got.Language != "en" {
+		t.Errorf("mergeChunkedTranscripts Language = %q, want %q", got.Language, "en")
+	}
+	if got.Duration != 608 {
+		t.Errorf("mergeChunkedTranscripts Duration = %v, want 608", got.Duration)
+	}
+}
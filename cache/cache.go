@@ -0,0 +1,16 @@
+// Package cache provides a pluggable transcript cache keyed by YouTube
+// video ID, so repeat requests for the same video skip yt-dlp/Whisper
+// entirely.
+package cache
+
+// Cache is implemented by each storage backend (filesystem, S3, ...).
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached value for videoID and whether it was found.
+	Get(videoID string) ([]byte, bool)
+	// Put stores value under videoID, overwriting any existing entry.
+	Put(videoID string, value []byte) error
+	// Delete removes the entry for videoID, if any. It is not an error to
+	// delete an entry that doesn't exist.
+	Delete(videoID string) error
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FSCache is a Cache backed by JSON files on the local filesystem, one
+// per video ID, under Dir.
+type FSCache struct {
+	Dir string
+}
+
+// NewFSCache returns an FSCache rooted at dir, creating dir if necessary.
+func NewFSCache(dir string) (*FSCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return &FSCache{Dir: dir}, nil
+}
+
+// path builds the on-disk path for videoID, rejecting any ID containing a
+// path separator or "..". Callers are expected to pass IDs already
+// validated by youtube.ExtractVideoID, but a cache backend shouldn't trust
+// that as its only line of defense against writing/reading outside Dir.
+func (c *FSCache) path(videoID string) (string, error) {
+	if strings.ContainsAny(videoID, `/\`) || strings.Contains(videoID, "..") {
+		return "", fmt.Errorf("invalid cache key %q", videoID)
+	}
+	return filepath.Join(c.Dir, videoID+".json"), nil
+}
+
+// Get implements Cache.
+func (c *FSCache) Get(videoID string) ([]byte, bool) {
+	path, err := c.path(videoID)
+	if err != nil {
+		log.Printf("FSCache: %v", err)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			// Anything other than a clean miss (permissions, disk errors, a
+			// corrupt file) still falls back to regenerating the transcript,
+			// but log it -- otherwise a broken cache is indistinguishable
+			// from a cold one and silently costs a Whisper/yt-dlp run on
+			// every request.
+			log.Printf("FSCache: error reading %s: %v", videoID, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// Put implements Cache.
+func (c *FSCache) Put(videoID string, value []byte) error {
+	path, err := c.path(videoID)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, value, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	return nil
+}
+
+// Delete implements Cache.
+func (c *FSCache) Delete(videoID string) error {
+	path, err := c.path(videoID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete cache entry: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Cache is a Cache backed by an S3 bucket, storing one JSON object per
+// video ID under Prefix via a single PutObject/GetObject call -- transcript
+// payloads are small enough that a multipart upload isn't warranted.
+type S3Cache struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Cache builds an S3Cache for bucket, loading AWS credentials and
+// region from the default credential chain / environment.
+func NewS3Cache(ctx context.Context, bucket, prefix string) (*S3Cache, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	return &S3Cache{
+		client: s3.NewFromConfig(cfg),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (c *S3Cache) key(videoID string) string {
+	if c.prefix == "" {
+		return videoID + ".json"
+	}
+	return c.prefix + "/" + videoID + ".json"
+}
+
+// Get implements Cache.
+func (c *S3Cache) Get(videoID string) ([]byte, bool) {
+	out, err := c.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(videoID)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if !errors.As(err, &noSuchKey) {
+			// Anything other than a clean miss (permissions, throttling,
+			// network) still falls back to regenerating the transcript,
+			// but log it -- otherwise a broken cache is indistinguishable
+			// from a cold one and silently costs a Whisper/yt-dlp run on
+			// every request.
+			log.Printf("S3Cache: error fetching %s: %v", videoID, err)
+		}
+		return nil, false
+	}
+	defer out.Body.Close()
+
+	buf := &bytes.Buffer{}
+	if _, err := buf.ReadFrom(out.Body); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// Put implements Cache.
+func (c *S3Cache) Put(videoID string, value []byte) error {
+	_, err := c.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(c.key(videoID)),
+		Body:        bytes.NewReader(value),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put cache entry: %v", err)
+	}
+	return nil
+}
+
+// Delete implements Cache.
+func (c *S3Cache) Delete(videoID string) error {
+	_, err := c.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(videoID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete cache entry: %v", err)
+	}
+	return nil
+}
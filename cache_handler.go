@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/davodey/avi-backend/cache"
+	"github.com/davodey/avi-backend/youtube"
+)
+
+// newCacheFromConfig builds the transcript cache backend selected by
+// cfg.CacheBackend.
+func newCacheFromConfig(cfg Config) (cache.Cache, error) {
+	switch cfg.CacheBackend {
+	case "s3":
+		if cfg.CacheBucket == "" {
+			return nil, fmt.Errorf("CACHE_S3_BUCKET is required when CACHE_BACKEND=s3")
+		}
+		return cache.NewS3Cache(context.Background(), cfg.CacheBucket, cfg.CachePrefix)
+	case "fs", "":
+		return cache.NewFSCache(cfg.CacheDir)
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", cfg.CacheBackend)
+	}
+}
+
+// getCachedTranscribeResponse looks up a previously cached transcription
+// result for videoID.
+func getCachedTranscribeResponse(videoID string) (TranscribeResponse, bool) {
+	raw, ok := transcriptCache.Get(videoID)
+	if !ok {
+		return TranscribeResponse{}, false
+	}
+
+	var response TranscribeResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		log.Printf("Error decoding cached transcript for %s: %v", videoID, err)
+		return TranscribeResponse{}, false
+	}
+	return response, true
+}
+
+// putCachedTranscribeResponse stores a transcription result under videoID.
+// Failures are logged but otherwise non-fatal -- the caller already has a
+// valid response to return to the client.
+func putCachedTranscribeResponse(videoID string, response TranscribeResponse) {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error encoding transcript for cache %s: %v", videoID, err)
+		return
+	}
+	if err := transcriptCache.Put(videoID, raw); err != nil {
+		log.Printf("Error writing transcript to cache for %s: %v", videoID, err)
+	}
+}
+
+// transcribeVideoHandler handles DELETE /api/transcribe/{videoID}, which
+// invalidates any cached transcript for that video.
+func transcribeVideoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	videoID := strings.TrimPrefix(r.URL.Path, "/api/transcribe/")
+	if videoID == "" || strings.Contains(videoID, "/") {
+		sendError(w, "Invalid video ID", http.StatusBadRequest)
+		return
+	}
+
+	// A path value that youtube.ExtractVideoID recognizes is reduced to its
+	// video ID; anything else is used as given. Values containing "/" (and
+	// so full YouTube URLs) are already rejected above.
+	if id, err := youtube.ExtractVideoID(videoID); err == nil {
+		videoID = id
+	}
+
+	if err := transcriptCache.Delete(videoID); err != nil {
+		log.Printf("Error deleting cache entry for %s: %v", videoID, err)
+		sendError(w, "Failed to invalidate cache entry", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// negotiateFormat determines the desired response format for a transcribe
+// request. The explicit ?format= query parameter takes priority over the
+// Accept header; both default to "json".
+func negotiateFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return normalizeFormat(format)
+	}
+	return normalizeFormat(r.Header.Get("Accept"))
+}
+
+func normalizeFormat(value string) string {
+	value = strings.ToLower(value)
+	switch {
+	case strings.Contains(value, "vtt"):
+		return "vtt"
+	case strings.Contains(value, "srt") || strings.Contains(value, "subrip"):
+		return "srt"
+	case strings.Contains(value, "text/plain"), value == "text":
+		return "text"
+	default:
+		return "json"
+	}
+}
+
+// writeTranscribeResponse renders response in whichever format the request
+// negotiated: the default JSON envelope, WebVTT, SRT, or plain text.
+func writeTranscribeResponse(w http.ResponseWriter, r *http.Request, response TranscribeResponse) {
+	switch negotiateFormat(r) {
+	case "vtt":
+		w.Header().Set("Content-Type", "text/vtt")
+		fmt.Fprint(w, renderWebVTT(response.Transcript))
+	case "srt":
+		w.Header().Set("Content-Type", "application/x-subrip")
+		fmt.Fprint(w, renderSRT(response.Transcript))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, response.Transcript.Text)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// renderWebVTT formats segments as a WebVTT subtitle file, the inverse of
+// parseVTTTime.
+func renderWebVTT(data TranscriptData) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+
+	for _, seg := range data.Segments {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatVTTTime(seg.Start), formatVTTTime(seg.End), seg.Text)
+	}
+	return b.String()
+}
+
+// renderSRT formats segments as a SubRip (.srt) file with 1-indexed,
+// comma-separated millisecond timestamps.
+func renderSRT(data TranscriptData) string {
+	var b strings.Builder
+
+	for i, seg := range data.Segments {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatSRTTime(seg.Start), formatSRTTime(seg.End), seg.Text)
+	}
+	return b.String()
+}
+
+// formatVTTTime converts seconds to WebVTT's "HH:MM:SS.mmm" form.
+func formatVTTTime(seconds float64) string {
+	return formatTimestamp(seconds, '.')
+}
+
+// formatSRTTime converts seconds to SRT's "HH:MM:SS,mmm" form.
+func formatSRTTime(seconds float64) string {
+	return formatTimestamp(seconds, ',')
+}
+
+func formatTimestamp(seconds float64, fractionSep rune) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	totalMillis := int64(seconds*1000 + 0.5)
+	hours := totalMillis / 3600000
+	totalMillis %= 3600000
+	minutes := totalMillis / 60000
+	totalMillis %= 60000
+	secs := totalMillis / 1000
+	millis := totalMillis % 1000
+
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", hours, minutes, secs, fractionSep, millis)
+}
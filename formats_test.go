@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds float64
+		sep     rune
+		want    string
+	}{
+		{name: "zero", seconds: 0, sep: '.', want: "00:00:00.000"},
+		{name: "sub-second rounding", seconds: 1.2345, sep: '.', want: "00:00:01.235"},
+		{name: "minutes and seconds", seconds: 125.5, sep: '.', want: "00:02:05.500"},
+		{name: "over an hour", seconds: 3661.001, sep: '.', want: "01:01:01.001"},
+		{name: "negative clamps to zero", seconds: -5, sep: '.', want: "00:00:00.000"},
+		{name: "SRT uses a comma separator", seconds: 90, sep: ',', want: "00:01:30,000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTimestamp(tt.seconds, tt.sep); got != tt.want {
+				t.Errorf("formatTimestamp(%v, %q) = %q, want %q", tt.seconds, tt.sep, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	batchWorkerCount    = 3
+	defaultMaxVideos    = 50
+	defaultMaxTotalSecs = 6 * 60 * 60 // 6 hours of combined video duration
+)
+
+// videoEntry is one row of a yt-dlp --flat-playlist --dump-json enumeration.
+type videoEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Duration int    `json:"duration"`
+}
+
+// VideoResult is the outcome of transcribing a single video within a batch
+// job.
+type VideoResult struct {
+	VideoID  string              `json:"video_id"`
+	Title    string              `json:"title"`
+	Status   string              `json:"status"` // "pending", "running", "done", "error"
+	Error    string              `json:"error,omitempty"`
+	Response *TranscribeResponse `json:"response,omitempty"`
+}
+
+// BatchJob tracks the state of one playlist/channel batch transcription.
+type BatchJob struct {
+	ID      string
+	mu      sync.Mutex
+	Results []VideoResult
+}
+
+// status summarizes pending/running/done counts for the job's API
+// representation.
+func (j *BatchJob) status() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	counts := map[string]int{"pending": 0, "running": 0, "done": 0, "error": 0}
+	for _, r := range j.Results {
+		counts[r.Status]++
+	}
+
+	results := make([]VideoResult, len(j.Results))
+	copy(results, j.Results)
+
+	return map[string]interface{}{
+		"id":      j.ID,
+		"total":   len(j.Results),
+		"counts":  counts,
+		"results": results,
+	}
+}
+
+func (j *BatchJob) setResult(i int, result VideoResult) {
+	j.mu.Lock()
+	j.Results[i] = result
+	j.mu.Unlock()
+}
+
+var (
+	jobStoreMu sync.Mutex
+	jobStore   = map[string]*BatchJob{}
+)
+
+// newJobID returns a random hex job ID.
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", buf), nil
+}
+
+// batchTranscribeRequest is the body of POST /api/transcribe/batch.
+type batchTranscribeRequest struct {
+	URL             string   `json:"url"`
+	Languages       []string `json:"languages"`
+	MaxVideos       int      `json:"max_videos"`
+	MaxTotalSeconds int      `json:"max_total_seconds"`
+}
+
+// transcribeBatchHandler enumerates a playlist or channel URL and enqueues
+// each video into the existing single-video transcription pipeline, bounded
+// by per-job video count and total duration limits.
+func transcribeBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req batchTranscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !isValidYouTubeURL(req.URL) {
+		sendError(w, "Invalid YouTube URL", http.StatusBadRequest)
+		return
+	}
+	if req.MaxVideos <= 0 {
+		req.MaxVideos = defaultMaxVideos
+	}
+	if req.MaxTotalSeconds <= 0 {
+		req.MaxTotalSeconds = defaultMaxTotalSecs
+	}
+
+	entries, err := enumeratePlaylist(req.URL, req.MaxVideos, req.MaxTotalSeconds)
+	if err != nil {
+		log.Printf("Error enumerating playlist %s: %v", req.URL, err)
+		sendError(w, "Failed to enumerate playlist/channel. Check that the URL is valid and public.", http.StatusBadRequest)
+		return
+	}
+	if len(entries) == 0 {
+		sendError(w, "No videos found at that URL", http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := newJobID()
+	if err != nil {
+		sendError(w, "Failed to create job", http.StatusInternalServerError)
+		return
+	}
+
+	job := &BatchJob{ID: jobID, Results: make([]VideoResult, len(entries))}
+	for i, e := range entries {
+		job.Results[i] = VideoResult{VideoID: e.ID, Title: e.Title, Status: "pending"}
+	}
+
+	jobStoreMu.Lock()
+	jobStore[jobID] = job
+	jobStoreMu.Unlock()
+
+	go runBatchJob(job, entries, req.Languages)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"job_id": jobID})
+}
+
+// runBatchJob transcribes each entry through a bounded worker pool, reusing
+// the same getVideoMetadata/getYouTubeCaptions/downloadYouTubeAudio/
+// transcribeAudioChunked pipeline as the single-video endpoint.
+func runBatchJob(job *BatchJob, entries []videoEntry, languages []string) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchWorkerCount)
+
+	for i, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, entry videoEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			job.setResult(i, VideoResult{VideoID: entry.ID, Title: entry.Title, Status: "running"})
+
+			videoURL := "https://www.youtube.com/watch?v=" + entry.ID
+			response, err := transcribeSingleVideo(videoURL, languages)
+			if err != nil {
+				job.setResult(i, VideoResult{VideoID: entry.ID, Title: entry.Title, Status: "error", Error: err.Error()})
+				return
+			}
+			job.setResult(i, VideoResult{VideoID: entry.ID, Title: entry.Title, Status: "done", Response: &response})
+		}(i, entry)
+	}
+
+	wg.Wait()
+}
+
+// jobsRouter dispatches /api/jobs/{id} and /api/jobs/{id}/results.ndjson,
+// since both share the "/api/jobs/" mux prefix.
+func jobsRouter(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/results.ndjson") {
+		jobResultsHandler(w, r)
+		return
+	}
+	jobStatusHandler(w, r)
+}
+
+// jobStatusHandler handles GET /api/jobs/{id}.
+func jobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+	job, ok := lookupJob(jobID)
+	if !ok {
+		sendError(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job.status())
+}
+
+// jobResultsHandler handles GET /api/jobs/{id}/results.ndjson, streaming
+// every result completed so far as newline-delimited JSON.
+func jobResultsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/results.ndjson")
+	job, ok := lookupJob(jobID)
+	if !ok {
+		sendError(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	job.mu.Lock()
+	results := make([]VideoResult, len(job.Results))
+	copy(results, job.Results)
+	job.mu.Unlock()
+
+	for _, result := range results {
+		if result.Status != "done" {
+			continue
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("Error writing ndjson result for job %s: %v", jobID, err)
+			return
+		}
+	}
+}
+
+func lookupJob(jobID string) (*BatchJob, bool) {
+	jobStoreMu.Lock()
+	defer jobStoreMu.Unlock()
+	job, ok := jobStore[jobID]
+	return job, ok
+}
+
+// enumeratePlaylist lists a playlist/channel's videos via yt-dlp's flat
+// playlist mode, stopping once maxVideos entries or maxTotalSeconds of
+// combined duration would be exceeded.
+func enumeratePlaylist(url string, maxVideos, maxTotalSeconds int) ([]videoEntry, error) {
+	args := []string{"--flat-playlist", "--dump-json", url}
+	cmd := exec.Command("yt-dlp", args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to yt-dlp stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start yt-dlp: %v", err)
+	}
+
+	var entries []videoEntry
+	totalSeconds := 0
+	limitReached := false
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry videoEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if len(entries) >= maxVideos || totalSeconds+entry.Duration > maxTotalSeconds {
+			limitReached = true
+			break
+		}
+		entries = append(entries, entry)
+		totalSeconds += entry.Duration
+	}
+
+	if limitReached {
+		// A channel can have thousands of entries left to write once we've
+		// hit our limit; draining them all before Wait would defeat the
+		// point of the limit. Kill yt-dlp instead so Wait can reap it
+		// without blocking on its remaining --dump-json output.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return entries, nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("yt-dlp failed: %v", err)
+	}
+
+	return entries, nil
+}
+
+// transcribeSingleVideo runs the full caption-first/Whisper-fallback
+// pipeline for one video URL, independent of any HTTP request/response.
+func transcribeSingleVideo(videoURL string, languages []string) (TranscribeResponse, error) {
+	tempDir, err := os.MkdirTemp("", "avi-transcribe-*")
+	if err != nil {
+		return TranscribeResponse{}, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	metadata, err := getVideoMetadata(context.Background(), videoURL)
+	if err != nil {
+		return TranscribeResponse{}, fmt.Errorf("failed to fetch video metadata: %v", err)
+	}
+
+	transcript, source, err := getYouTubeCaptions(context.Background(), videoURL, languages)
+	if err != nil {
+		audioFile, err := downloadYouTubeAudio(videoURL, tempDir)
+		if err != nil {
+			return TranscribeResponse{}, fmt.Errorf("failed to download audio: %v", err)
+		}
+		transcript, err = transcribeAudioChunked(audioFile)
+		if err != nil {
+			return TranscribeResponse{}, fmt.Errorf("failed to transcribe audio: %v", err)
+		}
+		source = "whisper_api"
+	}
+
+	return TranscribeResponse{
+		OK:         true,
+		URL:        videoURL,
+		Video:      metadata,
+		Transcript: transcript,
+		Source:     source,
+	}, nil
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,9 +12,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/davodey/avi-backend/cache"
+	"github.com/davodey/avi-backend/youtube"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 )
@@ -23,11 +26,27 @@ import (
 type Config struct {
 	Port         string
 	OpenAIAPIKey string
+
+	// CacheBackend selects the transcript cache implementation: "fs"
+	// (default) or "s3".
+	CacheBackend string
+	CacheDir     string // used when CacheBackend == "fs"
+	CacheBucket  string // used when CacheBackend == "s3"
+	CachePrefix  string // used when CacheBackend == "s3"
+
+	// WhisperChunkThresholdBytes is the audio file size above which
+	// transcribeAudioChunked splits the file before uploading to Whisper.
+	WhisperChunkThresholdBytes int64
 }
 
 // TranscribeRequest represents the incoming transcription request
 type TranscribeRequest struct {
 	URL string `json:"url"`
+	// Languages is an ordered list of preferred caption language codes
+	// (e.g. "en", "es"). The first language with any available track wins,
+	// preferring a manually-created track over an auto-generated (ASR) one.
+	// Defaults to []string{"en"} when empty.
+	Languages []string `json:"languages"`
 }
 
 // TranscribeResponse represents the transcription response
@@ -61,10 +80,11 @@ type TranscriptData struct {
 
 // TranscriptSegment represents a timestamped segment of the transcript
 type TranscriptSegment struct {
-	ID    int     `json:"id"`
-	Start float64 `json:"start"` // Start time in seconds
-	End   float64 `json:"end"`   // End time in seconds
-	Text  string  `json:"text"`
+	ID      int     `json:"id"`
+	Start   float64 `json:"start"` // Start time in seconds
+	End     float64 `json:"end"`   // End time in seconds
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker"` // Reserved for speaker diarization; empty until that's implemented
 }
 
 // HealthResponse represents the health check response
@@ -81,6 +101,7 @@ type ErrorResponse struct {
 }
 
 var config Config
+var transcriptCache cache.Cache
 
 func main() {
 	// Load environment variables
@@ -90,20 +111,35 @@ func main() {
 
 	// Initialize configuration
 	config = Config{
-		Port:         getEnv("PORT", "5055"),
-		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
+		Port:                       getEnv("PORT", "5055"),
+		OpenAIAPIKey:               getEnv("OPENAI_API_KEY", ""),
+		CacheBackend:               getEnv("CACHE_BACKEND", "fs"),
+		CacheDir:                   getEnv("CACHE_DIR", "./cache-data"),
+		CacheBucket:                getEnv("CACHE_S3_BUCKET", ""),
+		CachePrefix:                getEnv("CACHE_S3_PREFIX", "transcripts"),
+		WhisperChunkThresholdBytes: getEnvInt64("WHISPER_CHUNK_THRESHOLD_BYTES", defaultWhisperUploadBytes),
 	}
 
 	if config.OpenAIAPIKey == "" {
 		log.Fatal("OPENAI_API_KEY environment variable is required")
 	}
 
+	var err error
+	transcriptCache, err = newCacheFromConfig(config)
+	if err != nil {
+		log.Fatalf("Failed to initialize transcript cache: %v", err)
+	}
+
 	// Create router
 	mux := http.NewServeMux()
 
 	// Register routes
 	mux.HandleFunc("/api/health", healthHandler)
 	mux.HandleFunc("/api/transcribe", transcribeHandler)
+	mux.HandleFunc("/api/transcribe/stream", transcribeStreamHandler)
+	mux.HandleFunc("/api/transcribe/batch", transcribeBatchHandler)
+	mux.HandleFunc("/api/transcribe/", transcribeVideoHandler)
+	mux.HandleFunc("/api/jobs/", jobsRouter)
 
 	// Setup CORS
 	handler := cors.New(cors.Options{
@@ -157,6 +193,20 @@ func transcribeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	videoID, err := youtube.ExtractVideoID(req.URL)
+	if err != nil {
+		sendError(w, "Could not determine video ID from URL", http.StatusBadRequest)
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	if !force {
+		if response, ok := getCachedTranscribeResponse(videoID); ok {
+			writeTranscribeResponse(w, r, response)
+			return
+		}
+	}
+
 	// Create temporary directory
 	tempDir, err := os.MkdirTemp("", "avi-transcribe-*")
 	if err != nil {
@@ -167,7 +217,7 @@ func transcribeHandler(w http.ResponseWriter, r *http.Request) {
 	defer os.RemoveAll(tempDir)
 
 	// Get video metadata
-	metadata, err := getVideoMetadata(req.URL)
+	metadata, err := getVideoMetadata(r.Context(), req.URL)
 	if err != nil {
 		log.Printf("Error fetching video metadata: %v", err)
 		sendError(w, "Failed to fetch video metadata. The video may be private, age-restricted, or unavailable.", http.StatusBadRequest)
@@ -176,7 +226,7 @@ func transcribeHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Strategy 1: Try to get YouTube's native captions first (faster, no bot detection)
 	log.Printf("Attempting to fetch YouTube captions for: %s", metadata.Title)
-	transcript, source, err := getYouTubeCaptions(req.URL, tempDir)
+	transcript, source, err := getYouTubeCaptions(r.Context(), req.URL, req.Languages)
 
 	if err != nil {
 		// Strategy 2: Fall back to downloading audio and using Whisper
@@ -189,7 +239,7 @@ func transcribeHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		transcript, err = transcribeAudioWithTimestamps(audioFile)
+		transcript, err = transcribeAudioChunked(audioFile)
 		if err != nil {
 			log.Printf("Error transcribing audio: %v", err)
 			sendError(w, fmt.Sprintf("Failed to transcribe audio: %v", err), http.StatusInternalServerError)
@@ -207,64 +257,63 @@ func transcribeHandler(w http.ResponseWriter, r *http.Request) {
 		Source:     source,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	putCachedTranscribeResponse(videoID, response)
+
+	writeTranscribeResponse(w, r, response)
 }
 
 // getYouTubeCaptions tries to fetch YouTube's native captions/subtitles
-func getYouTubeCaptions(url, tempDir string) (TranscriptData, string, error) {
-	// Use Python youtube-transcript-api to get transcripts directly
-	// This bypasses all bot detection issues
-	cmd := exec.Command("python3", "get_transcript.py", url)
-	output, err := cmd.CombinedOutput()
+// directly (no external Python or yt-dlp dependency for this path). ctx is
+// threaded into every network call so a canceled request (e.g. a
+// disconnected SSE client) aborts them instead of running to completion.
+func getYouTubeCaptions(ctx context.Context, url string, preferredLanguages []string) (TranscriptData, string, error) {
+	if len(preferredLanguages) == 0 {
+		preferredLanguages = []string{"en"}
+	}
+
+	videoID, err := youtube.ExtractVideoID(url)
 	if err != nil {
-		return TranscriptData{}, "", fmt.Errorf("failed to get transcript: %v, output: %s", err, string(output))
+		return TranscriptData{}, "", fmt.Errorf("failed to extract video ID: %v", err)
 	}
 
-	// Parse JSON response
-	var result struct {
-		Success    bool   `json:"success"`
-		Error      string `json:"error"`
-		VideoID    string `json:"video_id"`
-		Transcript []struct {
-			Text     string  `json:"text"`
-			Start    float64 `json:"start"`
-			Duration float64 `json:"duration"`
-		} `json:"transcript"`
+	tracks, err := youtube.FetchCaptionTracks(ctx, videoID)
+	if err != nil {
+		return TranscriptData{}, "", fmt.Errorf("failed to fetch caption tracks: %v", err)
 	}
 
-	if err := json.Unmarshal(output, &result); err != nil {
-		return TranscriptData{}, "", fmt.Errorf("failed to parse transcript JSON: %v", err)
+	track, err := youtube.SelectTrack(tracks, preferredLanguages)
+	if err != nil {
+		return TranscriptData{}, "", fmt.Errorf("failed to select caption track: %v", err)
 	}
 
-	if !result.Success {
-		return TranscriptData{}, "", fmt.Errorf("transcript fetch failed: %s", result.Error)
+	entries, err := youtube.FetchTranscript(ctx, track)
+	if err != nil {
+		return TranscriptData{}, "", fmt.Errorf("failed to fetch transcript: %v", err)
 	}
 
-	// Convert to our TranscriptData format
 	var segments []TranscriptSegment
 	var fullText strings.Builder
 	totalDuration := 0.0
 
-	for i, entry := range result.Transcript {
+	for i, entry := range entries {
 		segments = append(segments, TranscriptSegment{
 			ID:    i + 1,
 			Start: entry.Start,
-			End:   entry.Start + entry.Duration,
+			End:   entry.Start + entry.Dur,
 			Text:  entry.Text,
 		})
 		fullText.WriteString(entry.Text)
 		fullText.WriteString(" ")
-		totalDuration = entry.Start + entry.Duration
+		totalDuration = entry.Start + entry.Dur
 	}
 
-	log.Printf("Successfully extracted YouTube transcript with %d segments", len(segments))
+	log.Printf("Successfully extracted YouTube transcript with %d segments (language=%s)", len(segments), track.LanguageCode)
 	return TranscriptData{
 		Text:     strings.TrimSpace(fullText.String()),
 		Segments: segments,
-		Language: "en",
+		Language: track.LanguageCode,
 		Duration: totalDuration,
-	}, "youtube_transcript_api", nil
+	}, "youtube_captions", nil
 }
 
 // parseVTTFile parses a WebVTT subtitle file
@@ -356,8 +405,10 @@ func parseVTTTime(timestamp string) float64 {
 	return hours*3600 + minutes*60 + seconds
 }
 
-// getVideoMetadata fetches video metadata using yt-dlp
-func getVideoMetadata(url string) (VideoMetadata, error) {
+// getVideoMetadata fetches video metadata using yt-dlp. ctx governs the
+// yt-dlp process, so canceling it (e.g. a disconnected SSE client) kills
+// the subprocess instead of letting it run to completion.
+func getVideoMetadata(ctx context.Context, url string) (VideoMetadata, error) {
 	// Using mweb client as recommended by yt-dlp documentation
 	args := []string{
 		"--dump-json",
@@ -367,7 +418,7 @@ func getVideoMetadata(url string) (VideoMetadata, error) {
 		url,
 	}
 
-	cmd := exec.Command("yt-dlp", args...)
+	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return VideoMetadata{}, fmt.Errorf("failed to fetch metadata: %v, output: %s", err, string(output))
@@ -430,40 +481,63 @@ func downloadYouTubeAudio(url, tempDir string) (string, error) {
 
 // transcribeAudioWithTimestamps transcribes an audio file using OpenAI Whisper API
 func transcribeAudioWithTimestamps(audioFilePath string) (TranscriptData, error) {
+	return transcribeAudioWithTimestampsProgress(context.Background(), audioFilePath, nil)
+}
+
+// transcribeAudioWithTimestampsProgress behaves like transcribeAudioWithTimestamps
+// but reports bytes actually sent to OpenAI vs. the file's total size via
+// onProgress, by streaming the multipart body straight into the request
+// instead of buffering it first. onProgress may be nil. The request is tied
+// to ctx, so canceling it (e.g. a disconnected SSE client) aborts the
+// in-flight upload instead of running it to completion for nobody.
+func transcribeAudioWithTimestampsProgress(ctx context.Context, audioFilePath string, onProgress func(read, total int64)) (TranscriptData, error) {
 	file, err := os.Open(audioFilePath)
 	if err != nil {
 		return TranscriptData{}, fmt.Errorf("failed to open audio file: %v", err)
 	}
-	defer file.Close()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(audioFilePath))
+	info, err := file.Stat()
 	if err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to create form file: %v", err)
-	}
-	if _, err := io.Copy(part, file); err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to copy file data: %v", err)
+		file.Close()
+		return TranscriptData{}, fmt.Errorf("failed to stat audio file: %v", err)
 	}
 
-	if err := writer.WriteField("model", "whisper-1"); err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to write model field: %v", err)
-	}
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
 
-	if err := writer.WriteField("response_format", "verbose_json"); err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to write response_format field: %v", err)
-	}
+	go func() {
+		defer file.Close()
+		defer pipeWriter.Close()
 
-	if err := writer.WriteField("timestamp_granularities[]", "segment"); err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to write timestamp_granularities field: %v", err)
-	}
+		part, err := writer.CreateFormFile("file", filepath.Base(audioFilePath))
+		if err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to create form file: %v", err))
+			return
+		}
+		if _, err := io.Copy(part, newProgressReader(file, info.Size(), onProgress)); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to copy file data: %v", err))
+			return
+		}
 
-	if err := writer.Close(); err != nil {
-		return TranscriptData{}, fmt.Errorf("failed to close multipart writer: %v", err)
-	}
+		if err := writer.WriteField("model", "whisper-1"); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to write model field: %v", err))
+			return
+		}
+		if err := writer.WriteField("response_format", "verbose_json"); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to write response_format field: %v", err))
+			return
+		}
+		if err := writer.WriteField("timestamp_granularities[]", "segment"); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to write timestamp_granularities field: %v", err))
+			return
+		}
+		if err := writer.Close(); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to close multipart writer: %v", err))
+			return
+		}
+	}()
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/audio/transcriptions", body)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/audio/transcriptions", pipeReader)
 	if err != nil {
 		return TranscriptData{}, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -546,3 +620,18 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt64 gets an environment variable parsed as an int64, falling
+// back to defaultValue if it's unset or not a valid integer.
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Warning: invalid value for %s (%q), using default of %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sseWriter serializes writes to an http.ResponseWriter/Flusher pair so the
+// handler goroutine (emitting progress events) and the heartbeat goroutine
+// (emitting keep-alive comments) never interleave bytes mid-frame.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// send writes a single named Server-Sent Event and flushes it immediately
+// so the client sees progress as it happens.
+func (s *sseWriter) send(event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling SSE event %q: %v", event, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	s.flusher.Flush()
+}
+
+// heartbeat writes a comment-only SSE line to keep proxies from timing out
+// the connection.
+func (s *sseWriter) heartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.w, ": heartbeat\n\n")
+	s.flusher.Flush()
+}
+
+// progressReader wraps an io.Reader and reports cumulative bytes read
+// against a known total on every Read call, so callers can drive a
+// progress bar (e.g. the Whisper multipart upload in
+// transcribeAudioWithTimestampsProgress) without buffering the whole
+// stream first.
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	read     int64
+	onUpdate func(read, total int64)
+}
+
+func newProgressReader(r io.Reader, total int64, onUpdate func(read, total int64)) *progressReader {
+	return &progressReader{reader: r, total: total, onUpdate: onUpdate}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	if n > 0 {
+		p.read += int64(n)
+		if p.onUpdate != nil {
+			p.onUpdate(p.read, p.total)
+		}
+	}
+	return n, err
+}
+
+// transcribeStreamHandler mirrors transcribeHandler but reports progress
+// over Server-Sent Events instead of returning a single JSON response.
+func transcribeStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TranscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidYouTubeURL(req.URL) {
+		http.Error(w, "Invalid YouTube URL", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+
+	stopHeartbeat := startHeartbeat(sse, 15*time.Second)
+	defer stopHeartbeat()
+
+	ctx := r.Context()
+
+	tempDir, err := os.MkdirTemp("", "avi-transcribe-*")
+	if err != nil {
+		sse.send("error", map[string]string{"message": "Failed to create temporary directory"})
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	metadata, err := getVideoMetadata(ctx, req.URL)
+	if err != nil {
+		sse.send("error", map[string]string{"message": "Failed to fetch video metadata"})
+		return
+	}
+	sse.send("metadata_fetched", metadata)
+
+	sse.send("captions_attempt", map[string]string{"status": "trying"})
+	transcript, source, err := getYouTubeCaptions(ctx, req.URL, req.Languages)
+	if err != nil {
+		log.Printf("Captions not available for stream, falling back to audio download: %v", err)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		audioFile, err := downloadYouTubeAudioWithProgress(ctx, req.URL, tempDir, func(percent float64, raw string) {
+			sse.send("audio_download_progress", map[string]interface{}{"percent": percent, "raw": raw})
+		})
+		if err != nil {
+			sse.send("error", map[string]string{"message": "Failed to download audio"})
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		sse.send("whisper_upload", map[string]string{"status": "uploading"})
+		transcript, err = transcribeAudioChunkedProgress(ctx, audioFile, func(read, total int64) {
+			sse.send("whisper_progress", map[string]int64{"bytes_read": read, "bytes_total": total})
+		})
+		if err != nil {
+			sse.send("error", map[string]string{"message": fmt.Sprintf("Failed to transcribe audio: %v", err)})
+			return
+		}
+		source = "whisper_api"
+	}
+
+	for _, segment := range transcript.Segments {
+		sse.send("segment", segment)
+	}
+
+	sse.send("done", TranscribeResponse{
+		OK:         true,
+		URL:        req.URL,
+		Video:      metadata,
+		Transcript: transcript,
+		Source:     source,
+	})
+}
+
+// startHeartbeat emits a comment-only SSE line on an interval so
+// intermediate proxies don't time out the connection while a long
+// transcription job runs. It returns a function that stops the ticker.
+func startHeartbeat(sse *sseWriter, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				sse.heartbeat()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+var ytdlpPercentPattern = regexp.MustCompile(`\[download\]\s+(\d+(?:\.\d+)?)%`)
+
+// downloadYouTubeAudioWithProgress behaves like downloadYouTubeAudio but
+// parses yt-dlp's stdout percent lines and reports them via onProgress.
+// yt-dlp runs under ctx, so canceling it (e.g. a disconnected SSE client)
+// kills the in-flight download instead of letting it run to completion.
+func downloadYouTubeAudioWithProgress(ctx context.Context, url, tempDir string, onProgress func(percent float64, raw string)) (string, error) {
+	outputPath := filepath.Join(tempDir, "audio.mp3")
+
+	args := []string{
+		"-f", "bestaudio/best",
+		"-x",
+		"--audio-format", "mp3",
+		"-o", outputPath,
+		"--no-playlist",
+		"--extractor-args", "youtube:player_client=mweb",
+		url,
+	}
+
+	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to yt-dlp stdout: %v", err)
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start yt-dlp: %v", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(bufio.ScanLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := ytdlpPercentPattern.FindStringSubmatch(line); match != nil {
+			if percent, err := strconv.ParseFloat(match[1], 64); err == nil && onProgress != nil {
+				onProgress(percent, line)
+			}
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("yt-dlp failed: %v", err)
+	}
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("audio file was not created")
+	}
+
+	return outputPath, nil
+}
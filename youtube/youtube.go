@@ -0,0 +1,243 @@
+// Package youtube provides a pure-Go client for extracting video IDs and
+// fetching caption tracks directly from YouTube, without shelling out to
+// any external tool.
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// TranscriptSegment is a single timestamped caption entry.
+type TranscriptSegment struct {
+	Start float64
+	Dur   float64
+	Text  string
+}
+
+// CaptionTrack describes one available caption track for a video.
+type CaptionTrack struct {
+	BaseURL      string
+	LanguageCode string
+	Name         string
+	IsASR        bool // true when the track is auto-generated ("asr")
+}
+
+// videoIDPattern matches a well-formed 11-character YouTube video ID. Every
+// extraction path validates against this, not just length, so a crafted
+// "v" query value like "../abcdefgh" can't smuggle path-traversal
+// characters into callers that use the ID to build a cache file path.
+var videoIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{11}$`)
+
+// pathVideoIDPatterns matches the URL shapes that carry the video ID in
+// the path rather than a query string, where there's no "v" param to parse.
+var pathVideoIDPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)youtu\.be/([a-zA-Z0-9_-]{11})`),
+	regexp.MustCompile(`(?i)youtube\.com/embed/([a-zA-Z0-9_-]{11})`),
+	regexp.MustCompile(`(?i)youtube\.com/v/([a-zA-Z0-9_-]{11})`),
+	regexp.MustCompile(`(?i)youtube\.com/shorts/([a-zA-Z0-9_-]{11})`),
+}
+
+// ExtractVideoID pulls the 11-character video ID out of any of the common
+// YouTube URL shapes (watch, youtu.be, embed, /v/, shorts).
+func ExtractVideoID(rawURL string) (string, error) {
+	if id := videoIDFromQueryParam(rawURL); id != "" {
+		return id, nil
+	}
+	for _, pattern := range pathVideoIDPatterns {
+		if match := pattern.FindStringSubmatch(rawURL); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", fmt.Errorf("could not extract video ID from URL: %s", rawURL)
+}
+
+// videoIDFromQueryParam extracts the "v" query parameter from watch-style
+// URLs (youtube.com/watch?v=...). This is parsed with net/url rather than a
+// regex so a second "v=" elsewhere in the query string (e.g. inside a
+// "list=" value) can't be mistaken for the video ID.
+func videoIDFromQueryParam(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	id := parsed.Query().Get("v")
+	if videoIDPattern.MatchString(id) {
+		return id
+	}
+	return ""
+}
+
+var playerResponsePattern = regexp.MustCompile(`ytInitialPlayerResponse\s*=\s*(\{.+?\});`)
+
+// FetchCaptionTracks loads the video's watch page and extracts the list of
+// available caption tracks from ytInitialPlayerResponse. ctx governs the
+// request, so a canceled ctx aborts the fetch instead of letting it run to
+// completion for nobody.
+func FetchCaptionTracks(ctx context.Context, videoID string) ([]CaptionTrack, error) {
+	watchURL := "https://www.youtube.com/watch?v=" + videoID
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, watchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	// A browser-like UA is required or YouTube serves a stripped-down page.
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch page: %w", err)
+	}
+
+	match := playerResponsePattern.FindSubmatch(body)
+	if match == nil {
+		return nil, fmt.Errorf("could not locate ytInitialPlayerResponse for video %s", videoID)
+	}
+
+	var playerResponse struct {
+		Captions struct {
+			PlayerCaptionsTracklistRenderer struct {
+				CaptionTracks []struct {
+					BaseURL      string `json:"baseUrl"`
+					LanguageCode string `json:"languageCode"`
+					Kind         string `json:"kind"`
+					Name         struct {
+						SimpleText string `json:"simpleText"`
+					} `json:"name"`
+				} `json:"captionTracks"`
+			} `json:"playerCaptionsTracklistRenderer"`
+		} `json:"captions"`
+	}
+
+	if err := json.Unmarshal(match[1], &playerResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse player response: %w", err)
+	}
+
+	tracks := playerResponse.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no caption tracks available for video %s", videoID)
+	}
+
+	result := make([]CaptionTrack, len(tracks))
+	for i, t := range tracks {
+		result[i] = CaptionTrack{
+			BaseURL:      t.BaseURL,
+			LanguageCode: t.LanguageCode,
+			Name:         t.Name.SimpleText,
+			IsASR:        t.Kind == "asr",
+		}
+	}
+	return result, nil
+}
+
+// SelectTrack picks the best caption track for the given preferred language
+// list, preferring a manually-created track over an auto-generated (ASR)
+// one for the same language. If none of the preferred languages are
+// available, it falls back to the first manual track, then the first track
+// of any kind.
+func SelectTrack(tracks []CaptionTrack, preferredLanguages []string) (CaptionTrack, error) {
+	if len(tracks) == 0 {
+		return CaptionTrack{}, fmt.Errorf("no caption tracks to select from")
+	}
+
+	for _, lang := range preferredLanguages {
+		var asrMatch *CaptionTrack
+		for i := range tracks {
+			t := &tracks[i]
+			if !strings.EqualFold(t.LanguageCode, lang) {
+				continue
+			}
+			if !t.IsASR {
+				return *t, nil
+			}
+			asrMatch = t
+		}
+		if asrMatch != nil {
+			return *asrMatch, nil
+		}
+	}
+
+	for _, t := range tracks {
+		if !t.IsASR {
+			return t, nil
+		}
+	}
+	return tracks[0], nil
+}
+
+// timedTextDocument mirrors the XML shape returned by YouTube's timedtext
+// endpoint (the default, non-srv3 format).
+type timedTextDocument struct {
+	XMLName xml.Name `xml:"transcript"`
+	Texts   []struct {
+		Start    float64 `xml:"start,attr"`
+		Duration float64 `xml:"dur,attr"`
+		Text     string  `xml:",chardata"`
+	} `xml:"text"`
+}
+
+// FetchTranscript downloads and parses the caption track's timedtext XML
+// into a flat list of segments. ctx governs the request, so a canceled ctx
+// aborts the fetch instead of letting it run to completion for nobody.
+func FetchTranscript(ctx context.Context, track CaptionTrack) ([]TranscriptSegment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, track.BaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch caption track: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read caption track: %w", err)
+	}
+
+	var doc timedTextDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse caption XML: %w", err)
+	}
+
+	segments := make([]TranscriptSegment, 0, len(doc.Texts))
+	for _, t := range doc.Texts {
+		text := unescapeEntities(t.Text)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		segments = append(segments, TranscriptSegment{
+			Start: t.Start,
+			Dur:   t.Duration,
+			Text:  text,
+		})
+	}
+	return segments, nil
+}
+
+var htmlEntityReplacer = strings.NewReplacer(
+	"&amp;#39;", "'",
+	"&#39;", "'",
+	"&amp;quot;", `"`,
+	"&quot;", `"`,
+	"&amp;", "&",
+)
+
+func unescapeEntities(s string) string {
+	return htmlEntityReplacer.Replace(strings.TrimSpace(s))
+}
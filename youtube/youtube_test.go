@@ -0,0 +1,121 @@
+package youtube
+
+import "testing"
+
+func TestExtractVideoID(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "watch URL",
+			url:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "watch URL with trailing params",
+			url:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=30",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "watch URL with v param after other params",
+			url:  "https://www.youtube.com/watch?list=PLsomething&v=dQw4w9WgXcQ",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "watch URL where another param's value contains v=",
+			url:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLsomething_with_v=abcdefghijk",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "youtu.be short link",
+			url:  "https://youtu.be/dQw4w9WgXcQ",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "embed URL",
+			url:  "https://www.youtube.com/embed/dQw4w9WgXcQ",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "shorts URL",
+			url:  "https://www.youtube.com/shorts/dQw4w9WgXcQ",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name: "malformed v param used for path traversal",
+			url:  "https://youtu.be/dQw4w9WgXcQ?v=../abcdefgh",
+			want: "dQw4w9WgXcQ",
+		},
+		{
+			name:    "no video ID present",
+			url:     "https://www.youtube.com/feed/trending",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractVideoID(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ExtractVideoID(%q) = %q, want error", tt.url, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractVideoID(%q) returned error: %v", tt.url, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractVideoID(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTrack(t *testing.T) {
+	tracks := []CaptionTrack{
+		{LanguageCode: "en", IsASR: true},
+		{LanguageCode: "es", IsASR: false},
+		{LanguageCode: "fr", IsASR: false},
+	}
+
+	t.Run("prefers manual track over ASR for the same language", func(t *testing.T) {
+		manualEn := append(append([]CaptionTrack{}, tracks...), CaptionTrack{LanguageCode: "en", IsASR: false})
+		got, err := SelectTrack(manualEn, []string{"en"})
+		if err != nil {
+			t.Fatalf("SelectTrack returned error: %v", err)
+		}
+		if got.LanguageCode != "en" || got.IsASR {
+			t.Errorf("SelectTrack = %+v, want manual en track", got)
+		}
+	})
+
+	t.Run("falls back to ASR when no manual track matches", func(t *testing.T) {
+		got, err := SelectTrack(tracks, []string{"en"})
+		if err != nil {
+			t.Fatalf("SelectTrack returned error: %v", err)
+		}
+		if got.LanguageCode != "en" || !got.IsASR {
+			t.Errorf("SelectTrack = %+v, want ASR en track", got)
+		}
+	})
+
+	t.Run("falls back to first manual track when preferred languages are absent", func(t *testing.T) {
+		got, err := SelectTrack(tracks, []string{"de"})
+		if err != nil {
+			t.Fatalf("SelectTrack returned error: %v", err)
+		}
+		if got.IsASR {
+			t.Errorf("SelectTrack = %+v, want a manual track", got)
+		}
+	})
+
+	t.Run("errors on empty track list", func(t *testing.T) {
+		if _, err := SelectTrack(nil, []string{"en"}); err == nil {
+			t.Error("SelectTrack(nil, ...) = nil error, want error")
+		}
+	})
+}